@@ -22,8 +22,9 @@ type visit struct {
 
 // Tests for deep equality using reflected types. The map argument tracks
 // comparisons that have already been seen, which allows short circuiting on
-// recursive types.
-func deepValueEqual(v1, v2 reflect.Value, visited map[visit]bool, depth int) (bool, error) {
+// recursive types. equalities may be nil, in which case no type gets a
+// custom comparison.
+func deepValueEqual(v1, v2 reflect.Value, visited map[visit]bool, equalities Equalities, depth int) (bool, error) {
 	if !v1.IsValid() || !v2.IsValid() {
 		if v1.IsValid() == v2.IsValid() {
 			return true, nil
@@ -34,6 +35,12 @@ func deepValueEqual(v1, v2 reflect.Value, visited map[visit]bool, depth int) (bo
 		return false, fmt.Errorf("Types for %v (type %v) and %v (type %v) do not match", v1, v1.Type(), v2, v2.Type())
 	}
 
+	if len(equalities) > 0 {
+		if found, equal, err := equalities.apply(v1.Type(), v1, v2); found {
+			return equal, err
+		}
+	}
+
 	// We want to avoid putting more in the visited map than we need to.
 	// For any possible reference cycle that might be encountered,
 	// hard(t) needs to return true for at least one of the types in the cycle.
@@ -75,7 +82,7 @@ func deepValueEqual(v1, v2 reflect.Value, visited map[visit]bool, depth int) (bo
 		// Will continue with normal value comparison in the default case
 	case reflect.Array:
 		for i := 0; i < v1.Len(); i++ {
-			if equal, err := deepValueEqual(v1.Index(i), v2.Index(i), visited, depth+1); !equal {
+			if equal, err := deepValueEqual(v1.Index(i), v2.Index(i), visited, equalities, depth+1); !equal {
 				return false, fmt.Errorf("Error in array %v: %s", v1, err.Error())
 			}
 		}
@@ -91,7 +98,7 @@ func deepValueEqual(v1, v2 reflect.Value, visited map[visit]bool, depth int) (bo
 			return true, fmt.Errorf("Both slices have the same pointer address (%v (ptr %d) vs %v (ptr %d))", v1, v1.Pointer(), v2, v2.Pointer())
 		}
 		for i := 0; i < v1.Len(); i++ {
-			if equal, err := deepValueEqual(v1.Index(i), v2.Index(i), visited, depth+1); !equal {
+			if equal, err := deepValueEqual(v1.Index(i), v2.Index(i), visited, equalities, depth+1); !equal {
 				return false, fmt.Errorf("Error in array %v: %s", v1, err.Error())
 			}
 		}
@@ -103,16 +110,43 @@ func deepValueEqual(v1, v2 reflect.Value, visited map[visit]bool, depth int) (bo
 			}
 			return false, fmt.Errorf("One interface is nil")
 		}
-		return deepValueEqual(v1.Elem(), v2.Elem(), visited, depth+1)
+		return deepValueEqual(v1.Elem(), v2.Elem(), visited, equalities, depth+1)
 	case reflect.Ptr:
 		if v1.Pointer() == v2.Pointer() {
 			return true, nil
 		}
-		return deepValueEqual(v1.Elem(), v2.Elem(), visited, depth+1)
+		return deepValueEqual(v1.Elem(), v2.Elem(), visited, equalities, depth+1)
 	case reflect.Struct:
+		policies := structFieldPolicies(v1.Type())
 		for i, n := 0, v1.NumField(); i < n; i++ {
-			if equal, err := deepValueEqual(v1.Field(i), v2.Field(i), visited, depth+1); !equal {
-				fieldName := v1.Type().Field(i).Name
+			policy := policies[i]
+			if policy.ignore {
+				continue
+			}
+			f1, f2 := v1.Field(i), v2.Field(i)
+			fieldName := v1.Type().Field(i).Name
+
+			if policy.nilAsEmpty && (f1.Kind() == reflect.Slice || f1.Kind() == reflect.Map) {
+				if f1.Len() == 0 && f2.Len() == 0 {
+					continue
+				}
+			}
+			if policy.unordered && (f1.Kind() == reflect.Slice || f1.Kind() == reflect.Array) {
+				if equal, err := unorderedEqual(f1, f2, visited, equalities, nil, depth+1); !equal {
+					return false, fmt.Errorf("Error in struct field %v: %s", fieldName, err.Error())
+				}
+				continue
+			}
+			// NaN values skip the tolerance check and go through the regular
+			// float comparison below.
+			if policy.hasTolerance && (f1.Kind() == reflect.Float32 || f1.Kind() == reflect.Float64) && !math.IsNaN(f1.Float()) && !math.IsNaN(f2.Float()) {
+				if diff := math.Abs(f1.Float() - f2.Float()); diff > policy.tolerance {
+					return false, fmt.Errorf("Error in struct field %v: values %v and %v differ by more than tolerance %v", fieldName, f1, f2, policy.tolerance)
+				}
+				continue
+			}
+
+			if equal, err := deepValueEqual(f1, f2, visited, equalities, depth+1); !equal {
 				return false, fmt.Errorf("Error in struct field %v: %s", fieldName, err.Error())
 			}
 		}
@@ -130,7 +164,7 @@ func deepValueEqual(v1, v2 reflect.Value, visited map[visit]bool, depth int) (bo
 		for _, k := range v1.MapKeys() {
 			val1 := v1.MapIndex(k)
 			val2 := v2.MapIndex(k)
-			equal, err := deepValueEqual(val1, val2, visited, depth+1)
+			equal, err := deepValueEqual(val1, val2, visited, equalities, depth+1)
 			if !val1.IsValid() || !val2.IsValid() || !equal {
 				return false, fmt.Errorf("Difference in values of %v: %s", k, err.Error())
 			}
@@ -213,16 +247,9 @@ func deepValueEqual(v1, v2 reflect.Value, visited map[visit]bool, depth int) (bo
 // equal rather than examining the values to which they point.
 // This ensures that DeepEqual terminates.
 func DeepEqual(x, y interface{}) (bool, error) {
-	if x == nil || y == nil {
-		if x == y {
-			return true, nil
-		}
-		return false, fmt.Errorf("Only one value is nil (%v vs %v)", x, y)
-	}
-	v1 := reflect.ValueOf(x)
-	v2 := reflect.ValueOf(y)
-	if v1.Type() != v2.Type() {
-		return false, fmt.Errorf("Types %v and %v do not match", v1.Type(), v2.Type())
+	diffs := DeepDiff(x, y)
+	if len(diffs) == 0 {
+		return true, nil
 	}
-	return deepValueEqual(v1, v2, make(map[visit]bool), 0)
+	return false, fmt.Errorf("%s", diffs[0].String())
 }
@@ -0,0 +1,380 @@
+package deepequal
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"unsafe"
+)
+
+// Diff describes a single mismatch found while comparing two values.
+//
+// Path identifies where in the value tree the mismatch occurred, using a
+// JSON-Pointer-like notation: struct fields are separated by "/" and slice,
+// array, or map indices are appended in brackets, e.g. "/Nested/Hobbies[2]"
+// or `/Users["alice"]`. The root value itself has an empty Path.
+type Diff struct {
+	Path   string
+	A, B   interface{}
+	Reason string
+}
+
+// String renders the diff as a single human-readable line.
+func (d Diff) String() string {
+	return fmt.Sprintf("%s: %s (%v != %v)", d.Path, d.Reason, d.A, d.B)
+}
+
+// options holds the configuration assembled from a chain of Option values.
+type options struct {
+	naNEqual            bool
+	nilEqualsEmptySlice bool
+	nilEqualsEmptyMap   bool
+	ignoreUnexported    map[reflect.Type]bool
+	maxDepth            int
+	floatAbsTolerance   float64
+	floatRelTolerance   float64
+	unorderedSlices     map[reflect.Type]bool
+}
+
+// defaultOptions returns the options in effect when DeepDiff is called
+// without any Option, matching the long-standing DeepEqual behavior: NaN is
+// treated as equal to NaN, nil and empty slices/maps are distinct, and there
+// is no depth limit or float tolerance.
+func defaultOptions() options {
+	return options{naNEqual: true}
+}
+
+// Option configures the behavior of DeepDiff.
+type Option func(*options)
+
+// WithNaNEqual controls whether two NaN floating-point values are considered
+// equal. It defaults to true, matching DeepEqual's historical behavior.
+func WithNaNEqual(equal bool) Option {
+	return func(o *options) { o.naNEqual = equal }
+}
+
+// WithNilEqualsEmptySlice makes a nil slice compare equal to a non-nil,
+// zero-length slice of the same type, anywhere in the value tree.
+func WithNilEqualsEmptySlice(equal bool) Option {
+	return func(o *options) { o.nilEqualsEmptySlice = equal }
+}
+
+// WithNilEqualsEmptyMap makes a nil map compare equal to a non-nil,
+// zero-length map of the same type, anywhere in the value tree.
+func WithNilEqualsEmptyMap(equal bool) Option {
+	return func(o *options) { o.nilEqualsEmptyMap = equal }
+}
+
+// WithIgnoreUnexported skips all unexported fields of the given types during
+// comparison, rather than recursing into them. Pass zero values of the types
+// to ignore, e.g. WithIgnoreUnexported(time.Time{}).
+func WithIgnoreUnexported(types ...interface{}) Option {
+	return func(o *options) {
+		if o.ignoreUnexported == nil {
+			o.ignoreUnexported = make(map[reflect.Type]bool, len(types))
+		}
+		for _, t := range types {
+			o.ignoreUnexported[reflect.TypeOf(t)] = true
+		}
+	}
+}
+
+// WithMaxDepth limits how many levels of recursion DeepDiff will perform
+// before reporting a diff instead of descending further. A value of 0 (the
+// default) means no limit.
+func WithMaxDepth(depth int) Option {
+	return func(o *options) { o.maxDepth = depth }
+}
+
+// WithFloatTolerance makes floating-point comparisons approximate: two
+// floats a and b are considered equal if |a-b| <= abs + rel*max(|a|, |b|).
+// It applies to every float in the value tree except struct fields carrying a
+// `deepequal:"tolerance=..."` tag, which are compared with the tag's
+// tolerance instead.
+func WithFloatTolerance(abs, rel float64) Option {
+	return func(o *options) {
+		o.floatAbsTolerance = abs
+		o.floatRelTolerance = rel
+	}
+}
+
+// WithUnorderedSlices makes slices of the given types compare as multisets
+// rather than ordered sequences, anywhere they occur in the value tree. Pass
+// zero values of the slice types to mark, e.g.
+// WithUnorderedSlices([]string{}). This has the same effect as the
+// `deepequal:"unordered"` struct tag, but applies regardless of where the
+// slice appears rather than only on a tagged field.
+func WithUnorderedSlices(types ...interface{}) Option {
+	return func(o *options) {
+		if o.unorderedSlices == nil {
+			o.unorderedSlices = make(map[reflect.Type]bool, len(types))
+		}
+		for _, t := range types {
+			o.unorderedSlices[reflect.TypeOf(t)] = true
+		}
+	}
+}
+
+// DeepDiff walks x and y and returns every mismatch found, rather than
+// stopping at the first one. It uses the same notion of deep equality as
+// DeepEqual; DeepEqual is in fact defined in terms of DeepDiff, returning
+// true exactly when DeepDiff reports no diffs.
+func DeepDiff(x, y interface{}, opts ...Option) []Diff {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if x == nil || y == nil {
+		if x == y {
+			return nil
+		}
+		return []Diff{{A: x, B: y, Reason: "only one value is nil"}}
+	}
+	v1 := reflect.ValueOf(x)
+	v2 := reflect.ValueOf(y)
+	if v1.Type() != v2.Type() {
+		return []Diff{{A: x, B: y, Reason: fmt.Sprintf("types %v and %v do not match", v1.Type(), v2.Type())}}
+	}
+
+	var diffs []Diff
+	deepValueDiff("", v1, v2, make(map[visit]bool), &o, 0, &diffs)
+	return diffs
+}
+
+// DeepEqualWithOptions is like DeepEqual, but accepts Options to configure
+// NaN, nil-vs-empty, unexported-field, depth, and float tolerance behavior.
+func DeepEqualWithOptions(x, y interface{}, opts ...Option) (bool, error) {
+	diffs := DeepDiff(x, y, opts...)
+	if len(diffs) == 0 {
+		return true, nil
+	}
+	return false, fmt.Errorf("%s", diffs[0].String())
+}
+
+// interfaceOf returns v's value as an interface{}, or nil if v is invalid or
+// its contents cannot be accessed (e.g. an unexported struct field).
+func interfaceOf(v reflect.Value) interface{} {
+	if !v.IsValid() || !v.CanInterface() {
+		return nil
+	}
+	return v.Interface()
+}
+
+// mapKeyPath appends a map key to path in bracket notation, quoting string
+// keys to match the common JSON-Pointer-like convention.
+func mapKeyPath(path string, key reflect.Value) string {
+	if key.Kind() == reflect.String {
+		return fmt.Sprintf("%s[%q]", path, key.String())
+	}
+	return fmt.Sprintf("%s[%v]", path, interfaceOf(key))
+}
+
+// deepValueDiff mirrors deepValueEqual, but instead of stopping at the first
+// mismatch it appends every mismatch it finds to diffs, tracking the path to
+// each one as it recurses.
+func deepValueDiff(path string, v1, v2 reflect.Value, visited map[visit]bool, opts *options, depth int, diffs *[]Diff) {
+	report := func(reason string) {
+		*diffs = append(*diffs, Diff{Path: path, A: interfaceOf(v1), B: interfaceOf(v2), Reason: reason})
+	}
+
+	if !v1.IsValid() || !v2.IsValid() {
+		if v1.IsValid() != v2.IsValid() {
+			report("only one value is valid")
+		}
+		return
+	}
+	if v1.Type() != v2.Type() {
+		report(fmt.Sprintf("types do not match: %v vs %v", v1.Type(), v2.Type()))
+		return
+	}
+	if opts.maxDepth > 0 && depth > opts.maxDepth {
+		report(fmt.Sprintf("max depth %d exceeded", opts.maxDepth))
+		return
+	}
+
+	hard := func(k reflect.Kind) bool {
+		switch k {
+		case reflect.Map, reflect.Slice, reflect.Ptr, reflect.Interface:
+			return true
+		}
+		return false
+	}
+
+	if v1.CanAddr() && v2.CanAddr() && hard(v1.Kind()) {
+		addr1 := unsafe.Pointer(v1.UnsafeAddr())
+		addr2 := unsafe.Pointer(v2.UnsafeAddr())
+		if uintptr(addr1) > uintptr(addr2) {
+			addr1, addr2 = addr2, addr1
+		}
+		v := visit{addr1, addr2, v1.Type()}
+		if visited[v] {
+			return
+		}
+		visited[v] = true
+	}
+
+	switch v1.Kind() {
+	case reflect.Float32, reflect.Float64:
+		// Handle special NaN values: Go treats math.NaN() == math.NaN() as
+		// false, so we check for it explicitly unless the caller asked
+		// otherwise. NaN must be handled before the tolerance check below,
+		// since math.Abs(f1-f2) involving a NaN is never > tolerance and
+		// would otherwise be silently reported equal.
+		f1, f2 := v1.Float(), v2.Float()
+		nan1, nan2 := math.IsNaN(f1), math.IsNaN(f2)
+		if nan1 || nan2 {
+			if nan1 && nan2 && opts.naNEqual {
+				return
+			}
+			report("NaN does not equal a non-NaN value, or NaN equality is disabled")
+			return
+		}
+		if opts.floatAbsTolerance > 0 || opts.floatRelTolerance > 0 {
+			tolerance := opts.floatAbsTolerance + opts.floatRelTolerance*math.Max(math.Abs(f1), math.Abs(f2))
+			if math.Abs(f1-f2) > tolerance {
+				report(fmt.Sprintf("float values differ by more than tolerance %v", tolerance))
+			}
+			return
+		}
+		// Otherwise fall through to the generic comparison below.
+	case reflect.Array:
+		for i := 0; i < v1.Len(); i++ {
+			deepValueDiff(fmt.Sprintf("%s[%d]", path, i), v1.Index(i), v2.Index(i), visited, opts, depth+1, diffs)
+		}
+		return
+	case reflect.Slice:
+		if opts.unorderedSlices[v1.Type()] {
+			if equal, err := unorderedEqual(v1, v2, visited, nil, opts, depth+1); !equal {
+				reason := "unordered comparison failed"
+				if err != nil {
+					reason = err.Error()
+				}
+				report(reason)
+			}
+			return
+		}
+		if v1.IsNil() != v2.IsNil() {
+			if !(opts.nilEqualsEmptySlice && v1.Len() == 0 && v2.Len() == 0) {
+				report("one slice is nil")
+				return
+			}
+		}
+		if v1.Len() != v2.Len() {
+			report(fmt.Sprintf("length differs (%d vs %d)", v1.Len(), v2.Len()))
+			return
+		}
+		if v1.IsNil() == v2.IsNil() && v1.Len() > 0 && v1.Pointer() == v2.Pointer() {
+			return
+		}
+		for i := 0; i < v1.Len(); i++ {
+			deepValueDiff(fmt.Sprintf("%s[%d]", path, i), v1.Index(i), v2.Index(i), visited, opts, depth+1, diffs)
+		}
+		return
+	case reflect.Interface:
+		if v1.IsNil() || v2.IsNil() {
+			if v1.IsNil() != v2.IsNil() {
+				report("only one interface is nil")
+			}
+			return
+		}
+		deepValueDiff(path, v1.Elem(), v2.Elem(), visited, opts, depth+1, diffs)
+		return
+	case reflect.Ptr:
+		if v1.Pointer() == v2.Pointer() {
+			return
+		}
+		deepValueDiff(path, v1.Elem(), v2.Elem(), visited, opts, depth+1, diffs)
+		return
+	case reflect.Struct:
+		ignoreUnexported := opts.ignoreUnexported[v1.Type()]
+		policies := structFieldPolicies(v1.Type())
+		for i, n := 0, v1.NumField(); i < n; i++ {
+			field := v1.Type().Field(i)
+			if ignoreUnexported && field.PkgPath != "" {
+				continue
+			}
+			policy := policies[i]
+			if policy.ignore {
+				continue
+			}
+			f1, f2 := v1.Field(i), v2.Field(i)
+			fieldPath := path + "/" + field.Name
+
+			if policy.nilAsEmpty && (f1.Kind() == reflect.Slice || f1.Kind() == reflect.Map) {
+				if f1.Len() == 0 && f2.Len() == 0 {
+					continue
+				}
+			}
+			if policy.unordered && (f1.Kind() == reflect.Slice || f1.Kind() == reflect.Array) {
+				if equal, err := unorderedEqual(f1, f2, visited, nil, opts, depth+1); !equal {
+					reason := "unordered comparison failed"
+					if err != nil {
+						reason = err.Error()
+					}
+					*diffs = append(*diffs, Diff{Path: fieldPath, A: interfaceOf(f1), B: interfaceOf(f2), Reason: reason})
+				}
+				continue
+			}
+			// NaN values skip the tolerance check and go through the regular
+			// float comparison below, which applies the NaN policy.
+			if policy.hasTolerance && (f1.Kind() == reflect.Float32 || f1.Kind() == reflect.Float64) && !math.IsNaN(f1.Float()) && !math.IsNaN(f2.Float()) {
+				if diff := math.Abs(f1.Float() - f2.Float()); diff > policy.tolerance {
+					*diffs = append(*diffs, Diff{Path: fieldPath, A: interfaceOf(f1), B: interfaceOf(f2), Reason: fmt.Sprintf("differs by more than tolerance %v", policy.tolerance)})
+				}
+				continue
+			}
+
+			deepValueDiff(fieldPath, f1, f2, visited, opts, depth+1, diffs)
+		}
+		return
+	case reflect.Map:
+		if v1.IsNil() != v2.IsNil() {
+			if !(opts.nilEqualsEmptyMap && v1.Len() == 0 && v2.Len() == 0) {
+				report("one map is nil")
+				return
+			}
+		}
+		if v1.IsNil() == v2.IsNil() && v1.Len() > 0 && v1.Pointer() == v2.Pointer() {
+			return
+		}
+		// Keys are looked up via MapIndex's presence (IsValid), not a
+		// map[interface{}]bool keyed by k.Interface(): if this map was
+		// reached through an unexported struct field, its keys carry the
+		// same read-only flag and Interface() would panic.
+		for _, k := range v1.MapKeys() {
+			keyPath := mapKeyPath(path, k)
+			val2 := v2.MapIndex(k)
+			if !val2.IsValid() {
+				*diffs = append(*diffs, Diff{Path: keyPath, A: interfaceOf(v1.MapIndex(k)), B: nil, Reason: "key missing from other map"})
+				continue
+			}
+			deepValueDiff(keyPath, v1.MapIndex(k), val2, visited, opts, depth+1, diffs)
+		}
+		for _, k := range v2.MapKeys() {
+			if v1.MapIndex(k).IsValid() {
+				continue
+			}
+			*diffs = append(*diffs, Diff{Path: mapKeyPath(path, k), A: nil, B: interfaceOf(v2.MapIndex(k)), Reason: "key missing from other map"})
+		}
+		return
+	case reflect.Func:
+		if v1.IsNil() && v2.IsNil() {
+			return
+		}
+		report("functions cannot be compared")
+		return
+	default:
+		// Fall through to the generic comparison below.
+	}
+
+	if !v1.CanInterface() || !v2.CanInterface() {
+		if v1.CanInterface() != v2.CanInterface() {
+			report("only one value is interfaceable (maybe unexported)")
+		}
+		return
+	}
+	if v1.Interface() != v2.Interface() {
+		report("values differ")
+	}
+}
@@ -0,0 +1,86 @@
+package deepequal
+
+import "testing"
+
+type diffNested struct {
+	Str string
+}
+
+type diffStruct struct {
+	Int32   int32
+	Nested  diffNested
+	Hobbies []string
+	Users   map[string]diffNested
+}
+
+func TestDeepDiffCollectsAllMismatches(t *testing.T) {
+	a := diffStruct{
+		Int32:   1,
+		Nested:  diffNested{Str: "a"},
+		Hobbies: []string{"surfing", "reading"},
+		Users:   map[string]diffNested{"alice": {Str: "x"}},
+	}
+	b := diffStruct{
+		Int32:   2,
+		Nested:  diffNested{Str: "b"},
+		Hobbies: []string{"surfing", "skiing"},
+		Users:   map[string]diffNested{"alice": {Str: "y"}},
+	}
+
+	diffs := DeepDiff(a, b)
+	if len(diffs) != 4 {
+		t.Fatalf("expected 4 diffs, got %d: %v", len(diffs), diffs)
+	}
+
+	wantPaths := map[string]bool{
+		"/Int32":              false,
+		"/Nested/Str":         false,
+		"/Hobbies[1]":         false,
+		`/Users["alice"]/Str`: false,
+	}
+	for _, d := range diffs {
+		if _, ok := wantPaths[d.Path]; !ok {
+			t.Errorf("unexpected diff path: %s", d.Path)
+			continue
+		}
+		wantPaths[d.Path] = true
+	}
+	for path, found := range wantPaths {
+		if !found {
+			t.Errorf("expected a diff at path %s", path)
+		}
+	}
+}
+
+func TestDeepDiffNoMismatches(t *testing.T) {
+	a := diffStruct{Int32: 1}
+	if diffs := DeepDiff(a, a); len(diffs) != 0 {
+		t.Errorf("expected no diffs for equal values, got: %v", diffs)
+	}
+}
+
+func TestDeepEqualUsesDeepDiff(t *testing.T) {
+	if equal, err := DeepEqual(diffStruct{Int32: 1}, diffStruct{Int32: 2}); equal {
+		t.Errorf("expected mismatch to be reported")
+	} else if err == nil {
+		t.Errorf("expected a non-nil error describing the mismatch")
+	}
+}
+
+type withUnexportedMap struct {
+	Name string
+	m    map[string]int
+}
+
+// TestDeepDiffUnexportedMapFieldDoesNotPanic guards against a regression
+// where a map reached through an unexported field panicked: its keys carry
+// the same read-only flag as the field itself, so calling Interface() on
+// them is not allowed.
+func TestDeepDiffUnexportedMapFieldDoesNotPanic(t *testing.T) {
+	a := withUnexportedMap{Name: "a", m: map[string]int{"x": 1}}
+	b := withUnexportedMap{Name: "a", m: map[string]int{"x": 2}}
+
+	if diffs := DeepDiff(a, b); len(diffs) != 0 {
+		t.Errorf("expected differing values in an unexported map field to go unreported, like any other unexported value, got: %v", diffs)
+	}
+}
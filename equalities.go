@@ -0,0 +1,127 @@
+package deepequal
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Equalities is a map from type to a custom comparison function. It lets
+// callers override how a particular type is compared, which is useful for
+// types whose zero-value-based equality does not line up with what the
+// caller considers equal, e.g. protobuf messages, time.Time, or types that
+// need an approximate (tolerance-based) comparison.
+//
+// Based on the forked-reflect pattern used by k8s.io/apimachinery's
+// conversion.Equalities.
+type Equalities map[reflect.Type]reflect.Value
+
+// NewEqualities returns an empty set of equality functions.
+func NewEqualities() Equalities {
+	return Equalities{}
+}
+
+// EqualitiesOrDie is like NewEqualities, but adds the provided functions and
+// panics if any of them is invalid. It is meant for package-level var
+// initialization where an error cannot sensibly be handled.
+func EqualitiesOrDie(funcs ...interface{}) Equalities {
+	e := NewEqualities()
+	if err := e.AddFuncs(funcs...); err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// AddFuncs adds the provided equality functions, stopping and returning the
+// first error encountered.
+func (e Equalities) AddFuncs(funcs ...interface{}) error {
+	for _, f := range funcs {
+		if err := e.AddFunc(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddFunc adds an equality function that is used to compare values of the
+// function's parameter type. fn must be a function of the form:
+//
+//	func(T, T) bool
+//	func(T, T) (bool, error)
+//
+// where both parameters have the same type T. The function is consulted by
+// deepValueEqual before falling back to the default comparison, so it is
+// also used for any T nested inside other compared values.
+func (e Equalities) AddFunc(fn interface{}) error {
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func {
+		return fmt.Errorf("expected func, got: %v", ft)
+	}
+	if ft.NumIn() != 2 {
+		return fmt.Errorf("expected two 'in' params, got: %v", ft)
+	}
+	if ft.In(0) != ft.In(1) {
+		return fmt.Errorf("expected equal parameter types, got: %v", ft)
+	}
+	switch ft.NumOut() {
+	case 1:
+		if ft.Out(0) != reflect.TypeOf(true) {
+			return fmt.Errorf("expected bool return, got: %v", ft)
+		}
+	case 2:
+		if ft.Out(0) != reflect.TypeOf(true) {
+			return fmt.Errorf("expected bool as first return value, got: %v", ft)
+		}
+		if ft.Out(1) != reflect.TypeOf((*error)(nil)).Elem() {
+			return fmt.Errorf("expected error as second return value, got: %v", ft)
+		}
+	default:
+		return fmt.Errorf("expected one or two return values, got: %v", ft)
+	}
+	e[ft.In(0)] = fv
+	return nil
+}
+
+// apply calls the registered equality function for typ, if any, and reports
+// whether one was found and what it returned. If v1 or v2 was obtained
+// through an unexported field, it cannot be passed to the function (doing so
+// would panic), so apply reports not found and the caller falls back to the
+// default comparison instead.
+func (e Equalities) apply(typ reflect.Type, v1, v2 reflect.Value) (found bool, equal bool, err error) {
+	fn, ok := e[typ]
+	if !ok {
+		return false, false, nil
+	}
+	if !v1.CanInterface() || !v2.CanInterface() {
+		return false, false, nil
+	}
+	out := fn.Call([]reflect.Value{v1, v2})
+	equal = out[0].Bool()
+	if len(out) == 2 {
+		if errIface := out[1].Interface(); errIface != nil {
+			err = errIface.(error)
+		}
+	}
+	if !equal && err == nil {
+		err = fmt.Errorf("custom equality function for %v reported values are not equal: %v vs %v", typ, v1, v2)
+	}
+	return true, equal, err
+}
+
+// DeepEqual is like the package-level DeepEqual, but consults e for any type
+// that has a registered custom equality function before falling back to the
+// default reflection-based comparison.
+func (e Equalities) DeepEqual(x, y interface{}) (bool, error) {
+	if x == nil || y == nil {
+		if x == y {
+			return true, nil
+		}
+		return false, fmt.Errorf("Only one value is nil (%v vs %v)", x, y)
+	}
+	v1 := reflect.ValueOf(x)
+	v2 := reflect.ValueOf(y)
+	if v1.Type() != v2.Type() {
+		return false, fmt.Errorf("Types %v and %v do not match", v1.Type(), v2.Type())
+	}
+	return deepValueEqual(v1, v2, make(map[visit]bool), e, 0)
+}
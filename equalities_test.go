@@ -0,0 +1,78 @@
+package deepequal
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEqualitiesTimeOverride(t *testing.T) {
+	e := EqualitiesOrDie(
+		func(a, b time.Time) bool {
+			return a.Unix() == b.Unix()
+		},
+	)
+
+	a := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := a.In(time.FixedZone("other", 3600))
+
+	if equal, err := e.DeepEqual(a, b); !equal {
+		t.Errorf("expected times representing the same instant to be equal: %v", err)
+	}
+
+	c := a.Add(time.Second)
+	if equal, _ := e.DeepEqual(a, c); equal {
+		t.Errorf("expected times one second apart to be unequal")
+	}
+}
+
+func TestEqualitiesFloatTolerance(t *testing.T) {
+	const tolerance = 1e-6
+
+	e := EqualitiesOrDie(
+		func(a, b float64) (bool, error) {
+			return math.Abs(a-b) <= tolerance, nil
+		},
+	)
+
+	if equal, err := e.DeepEqual(1.0, 1.0+tolerance/2); !equal {
+		t.Errorf("expected values within tolerance to be equal: %v", err)
+	}
+	if equal, _ := e.DeepEqual(1.0, 2.0); equal {
+		t.Errorf("expected values outside tolerance to be unequal")
+	}
+}
+
+type withUnexportedTime struct {
+	Name    string
+	created time.Time
+}
+
+func TestEqualitiesSkipsUnexportedFieldInsteadOfPanicking(t *testing.T) {
+	e := EqualitiesOrDie(
+		func(a, b time.Time) bool {
+			return a.Unix() == b.Unix()
+		},
+	)
+
+	a := withUnexportedTime{Name: "a", created: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	b := withUnexportedTime{Name: "a", created: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	if equal, err := e.DeepEqual(a, b); !equal {
+		t.Errorf("expected unexported field to fall back to being skipped, not panic: %v", err)
+	}
+}
+
+func TestAddFuncRejectsInvalidSignatures(t *testing.T) {
+	e := NewEqualities()
+
+	if err := e.AddFunc(func(a int, b string) bool { return true }); err == nil {
+		t.Errorf("expected error for mismatched parameter types")
+	}
+	if err := e.AddFunc(func(a, b int) string { return "" }); err == nil {
+		t.Errorf("expected error for non-bool return value")
+	}
+	if err := e.AddFunc("not a func"); err == nil {
+		t.Errorf("expected error for non-func value")
+	}
+}
@@ -0,0 +1,89 @@
+package deepequal
+
+import (
+	"math"
+	"testing"
+)
+
+type withUnexported struct {
+	Name   string
+	secret []string
+}
+
+func TestWithNaNEqualFalse(t *testing.T) {
+	nan := math.NaN()
+	if equal, _ := DeepEqualWithOptions(nan, nan, WithNaNEqual(false)); equal {
+		t.Errorf("expected NaN to not equal NaN when WithNaNEqual(false) is set")
+	}
+	if equal, err := DeepEqualWithOptions(nan, nan); !equal {
+		t.Errorf("expected NaN to equal NaN by default: %v", err)
+	}
+}
+
+func TestWithNilEqualsEmptySlice(t *testing.T) {
+	var a []string
+	b := []string{}
+	if equal, _ := DeepEqualWithOptions(a, b); equal {
+		t.Errorf("expected nil and empty slice to differ by default")
+	}
+	if equal, err := DeepEqualWithOptions(a, b, WithNilEqualsEmptySlice(true)); !equal {
+		t.Errorf("expected nil and empty slice to be equal: %v", err)
+	}
+}
+
+func TestWithNilEqualsEmptyMap(t *testing.T) {
+	var a map[string]int
+	b := map[string]int{}
+	if equal, err := DeepEqualWithOptions(a, b, WithNilEqualsEmptyMap(true)); !equal {
+		t.Errorf("expected nil and empty map to be equal: %v", err)
+	}
+}
+
+func TestWithIgnoreUnexported(t *testing.T) {
+	a := withUnexported{Name: "a", secret: []string{"x"}}
+	b := withUnexported{Name: "a", secret: []string{"y", "z"}}
+	if equal, err := DeepEqualWithOptions(a, b, WithIgnoreUnexported(withUnexported{})); !equal {
+		t.Errorf("expected unexported field to be ignored: %v", err)
+	}
+
+	c := withUnexported{Name: "b", secret: []string{"x"}}
+	if equal, _ := DeepEqualWithOptions(a, c, WithIgnoreUnexported(withUnexported{})); equal {
+		t.Errorf("expected exported field mismatch to still be reported")
+	}
+}
+
+func TestWithMaxDepth(t *testing.T) {
+	type inner struct{ V int }
+	type outer struct{ Inner inner }
+
+	a := outer{Inner: inner{V: 1}}
+	b := outer{Inner: inner{V: 2}}
+
+	if equal, err := DeepEqualWithOptions(a, b, WithMaxDepth(1)); equal {
+		t.Errorf("expected diff to be reported even at the depth limit: %v", err)
+	}
+}
+
+func TestWithFloatTolerance(t *testing.T) {
+	if equal, err := DeepEqualWithOptions(1.0, 1.0005, WithFloatTolerance(0.001, 0)); !equal {
+		t.Errorf("expected values within tolerance to be equal: %v", err)
+	}
+	if equal, _ := DeepEqualWithOptions(1.0, 1.1, WithFloatTolerance(0.001, 0)); equal {
+		t.Errorf("expected values outside tolerance to differ")
+	}
+}
+
+// TestWithFloatToleranceNaNNotEqualToFinite guards against math.Abs(f1-f2)
+// silently being <= tolerance whenever exactly one operand is NaN, since
+// NaN > tolerance is always false.
+func TestWithFloatToleranceNaNNotEqualToFinite(t *testing.T) {
+	if equal, _ := DeepEqualWithOptions(math.NaN(), 5.0, WithFloatTolerance(0.001, 0)); equal {
+		t.Errorf("expected NaN to differ from a finite value even with a tolerance set")
+	}
+	if equal, _ := DeepEqualWithOptions(math.NaN(), math.NaN(), WithFloatTolerance(0.001, 0), WithNaNEqual(false)); equal {
+		t.Errorf("expected NaN to differ from NaN when WithNaNEqual(false) is set, even with a tolerance")
+	}
+	if equal, err := DeepEqualWithOptions(math.NaN(), math.NaN(), WithFloatTolerance(0.001, 0)); !equal {
+		t.Errorf("expected NaN to equal NaN by default, even with a tolerance set: %v", err)
+	}
+}
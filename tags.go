@@ -0,0 +1,344 @@
+package deepequal
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// fieldPolicy captures the per-field comparison behaviour requested via a
+// `deepequal:"..."` struct tag.
+type fieldPolicy struct {
+	ignore       bool
+	unordered    bool
+	nilAsEmpty   bool
+	tolerance    float64
+	hasTolerance bool
+}
+
+// fieldPolicyCache memoizes the parsed field policies for a struct type, so
+// the tag is only parsed once per type rather than on every comparison.
+var fieldPolicyCache sync.Map // map[reflect.Type][]fieldPolicy
+
+// structFieldPolicies returns the parsed `deepequal` tag policy for each
+// field of t, computing and caching it on first use. It panics if a tag is
+// malformed, since that is a programmer error in the struct definition.
+func structFieldPolicies(t reflect.Type) []fieldPolicy {
+	if cached, ok := fieldPolicyCache.Load(t); ok {
+		return cached.([]fieldPolicy)
+	}
+
+	policies := make([]fieldPolicy, t.NumField())
+	for i := range policies {
+		tag := t.Field(i).Tag.Get("deepequal")
+		policy, err := parseFieldPolicy(tag)
+		if err != nil {
+			panic(fmt.Sprintf("deepequal: invalid tag on %s.%s: %s", t, t.Field(i).Name, err.Error()))
+		}
+		policies[i] = policy
+	}
+
+	// Another goroutine may have raced us to compute the same entry; either
+	// value is equivalent, so just let LoadOrStore pick one.
+	actual, _ := fieldPolicyCache.LoadOrStore(t, policies)
+	return actual.([]fieldPolicy)
+}
+
+// parseFieldPolicy parses a single `deepequal:"..."` struct tag value into a
+// fieldPolicy. Supported options, separated by commas:
+//
+//	ignore          skip the field entirely
+//	unordered       compare slice/array fields as multisets
+//	nilAsEmpty      treat a nil slice/map as equal to an empty one
+//	tolerance=1e-6  compare float fields with the given absolute tolerance
+func parseFieldPolicy(tag string) (fieldPolicy, error) {
+	var p fieldPolicy
+	if tag == "" {
+		return p, nil
+	}
+	for _, opt := range strings.Split(tag, ",") {
+		switch {
+		case opt == "ignore":
+			p.ignore = true
+		case opt == "unordered":
+			p.unordered = true
+		case opt == "nilAsEmpty":
+			p.nilAsEmpty = true
+		case strings.HasPrefix(opt, "tolerance="):
+			v, err := strconv.ParseFloat(strings.TrimPrefix(opt, "tolerance="), 64)
+			if err != nil {
+				return p, fmt.Errorf("invalid tolerance %q: %s", opt, err.Error())
+			}
+			p.tolerance = v
+			p.hasTolerance = true
+		default:
+			return p, fmt.Errorf("unknown option %q", opt)
+		}
+	}
+	return p, nil
+}
+
+// unorderedEqual compares two slices or arrays as multisets: order does not
+// matter, only the multiset of elements. Elements of a comparable type whose
+// == operator reflects value equality (not e.g. pointer identity) are
+// counted in a map for an O(n) comparison. Otherwise it first tries greedily
+// matching each element of v1 against the first unmatched deeply-equal
+// element of v2, which is fast and succeeds in the common case; if that
+// fails it falls back to a full bipartite matching (bipartiteUnorderedEqual)
+// before concluding the slices genuinely differ, since a greedy match can
+// fail to find a pairing that does exist.
+//
+// opts may be nil, in which case element comparisons go through the legacy
+// deepValueEqual/equalities path; otherwise they go through deepValueDiff so
+// that options such as WithFloatTolerance or WithNilEqualsEmptySlice apply
+// to the elements being matched, the same as they would in ordered position.
+func unorderedEqual(v1, v2 reflect.Value, visited map[visit]bool, equalities Equalities, opts *options, depth int) (bool, error) {
+	if v1.Len() != v2.Len() {
+		return false, fmt.Errorf("unordered comparison: length differs (%d vs %d)", v1.Len(), v2.Len())
+	}
+	if v1.Kind() == reflect.Slice && v1.IsNil() != v2.IsNil() && (opts == nil || !opts.nilEqualsEmptySlice) {
+		return false, fmt.Errorf("unordered comparison: one slice is nil")
+	}
+
+	// The map-counting fast path below compares elements with Go's ==, which
+	// cannot honor custom equality functions or Options that would make it
+	// disagree with ==, such as WithFloatTolerance, and it mishandles NaN
+	// elements. Skip it in those cases and fall through to the slower but
+	// options-aware greedy/bipartite matching instead.
+	if elemType := v1.Type().Elem(); elemType.Comparable() && isValueComparable(elemType) && len(equalities) == 0 && optsAllowComparableFastPath(opts, elemType) {
+		counts := make(map[interface{}]int, v1.Len())
+		for i := 0; i < v1.Len(); i++ {
+			counts[v1.Index(i).Interface()]++
+		}
+		for i := 0; i < v2.Len(); i++ {
+			key := v2.Index(i).Interface()
+			counts[key]--
+			if counts[key] < 0 {
+				return false, fmt.Errorf("unordered comparison: no match found for element %v", key)
+			}
+		}
+		return true, nil
+	}
+
+	if equal, _ := greedyUnorderedEqual(v1, v2, visited, equalities, opts, depth); equal {
+		return true, nil
+	}
+	return bipartiteUnorderedEqual(v1, v2, visited, equalities, opts, depth)
+}
+
+// isValueComparable reports whether t's == operator corresponds to deep
+// value equality rather than identity. Pointers, interfaces, channels, and
+// unsafe.Pointer compare by identity even though they are Go-comparable, and
+// that identity leaks into any array or struct that contains them.
+func isValueComparable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Chan, reflect.UnsafePointer:
+		return false
+	case reflect.Array:
+		return isValueComparable(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if !isValueComparable(t.Field(i).Type) {
+				return false
+			}
+		}
+		return true
+	default:
+		return true
+	}
+}
+
+// optsAllowComparableFastPath reports whether opts leaves Go's == agreeing
+// with deepValueDiff for values of elemType, so the map-counting fast path
+// in unorderedEqual is safe to use. It disallows the fast path for element
+// types that contain a float, since a NaN map key is never found again by a
+// lookup and NaN elements would then go unmatched without being reported,
+// and whenever a configured option could make the two disagree: a float
+// tolerance, a depth limit that could truncate a comparison == always
+// performs in full, or per-type unexported-field skipping.
+func optsAllowComparableFastPath(opts *options, elemType reflect.Type) bool {
+	if containsFloat(elemType) {
+		return false
+	}
+	if opts == nil {
+		return true
+	}
+	if opts.floatAbsTolerance > 0 || opts.floatRelTolerance > 0 {
+		return false
+	}
+	if opts.maxDepth > 0 {
+		return false
+	}
+	if len(opts.ignoreUnexported) > 0 {
+		return false
+	}
+	return true
+}
+
+// containsFloat reports whether t is, or recursively contains, a
+// floating-point field or element.
+func containsFloat(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Array:
+		return containsFloat(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if containsFloat(t.Field(i).Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// candidateEqual checks whether v1 and v2 are equal when being tried as a
+// candidate pairing for an unordered match. When opts is non-nil it honors
+// the active Options (via deepValueDiff); otherwise it falls back to the
+// legacy deepValueEqual/equalities comparison.
+func candidateEqual(v1, v2 reflect.Value, visited map[visit]bool, equalities Equalities, opts *options, depth int) bool {
+	if opts != nil {
+		var diffs []Diff
+		deepValueDiff("", v1, v2, visited, opts, depth, &diffs)
+		return len(diffs) == 0
+	}
+	equal, _ := deepValueEqual(v1, v2, visited, equalities, depth)
+	return equal
+}
+
+// cloneVisited copies the ambient visited set so that independent candidate
+// comparisons (e.g. trying several possible (i, j) pairings of the same
+// elements) each start from the real cycle-detection state inherited from
+// their ancestors, without one candidate's in-progress state leaking into
+// another sibling candidate that is never actually encountered twice.
+func cloneVisited(visited map[visit]bool) map[visit]bool {
+	clone := make(map[visit]bool, len(visited))
+	for k, v := range visited {
+		clone[k] = v
+	}
+	return clone
+}
+
+// greedyUnorderedEqual matches each element of v1 against the first
+// unmatched deeply-equal element of v2. It is a fast path that succeeds
+// whenever a greedy assignment happens to work, but unlike
+// bipartiteUnorderedEqual it cannot guarantee finding a valid matching when
+// one exists.
+func greedyUnorderedEqual(v1, v2 reflect.Value, visited map[visit]bool, equalities Equalities, opts *options, depth int) (bool, error) {
+	matched := make([]bool, v2.Len())
+outer:
+	for i := 0; i < v1.Len(); i++ {
+		for j := 0; j < v2.Len(); j++ {
+			if matched[j] {
+				continue
+			}
+			if candidateEqual(v1.Index(i), v2.Index(j), cloneVisited(visited), equalities, opts, depth+1) {
+				matched[j] = true
+				continue outer
+			}
+		}
+		return false, fmt.Errorf("unordered comparison: no match found for element %v", v1.Index(i))
+	}
+	return true, nil
+}
+
+// bipartiteUnorderedEqual checks whether v1 and v2 (already known to have
+// equal length) can be paired up element-for-element under deep equality,
+// regardless of order. It builds the bipartite graph where i~j whenever
+// v1[i] is deeply equal to v2[j], and runs Hopcroft-Karp to look for a
+// perfect matching; this succeeds whenever any valid pairing exists, which a
+// greedy assignment can miss.
+func bipartiteUnorderedEqual(v1, v2 reflect.Value, visited map[visit]bool, equalities Equalities, opts *options, depth int) (bool, error) {
+	n := v1.Len()
+	adj := make([][]int, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if candidateEqual(v1.Index(i), v2.Index(j), cloneVisited(visited), equalities, opts, depth+1) {
+				adj[i] = append(adj[i], j)
+			}
+		}
+	}
+
+	matching, matchL := hopcroftKarp(adj, n)
+	if matching == n {
+		return true, nil
+	}
+
+	for i, j := range matchL {
+		if j == -1 {
+			return false, fmt.Errorf("unordered comparison: no match found for element %v", v1.Index(i))
+		}
+	}
+	return false, fmt.Errorf("unordered comparison: no perfect matching exists between the two slices")
+}
+
+// hopcroftKarp computes a maximum matching in the bipartite graph described
+// by adj (adj[i] lists the right-hand indices adjacent to left index i,
+// both sides sized n). It returns the size of the matching and, for each
+// left index, its matched right index or -1 if unmatched.
+func hopcroftKarp(adj [][]int, n int) (int, []int) {
+	const unmatched = -1
+	matchL := make([]int, n)
+	matchR := make([]int, n)
+	dist := make([]int, n)
+	for i := range matchL {
+		matchL[i] = unmatched
+	}
+	for j := range matchR {
+		matchR[j] = unmatched
+	}
+
+	bfs := func() bool {
+		queue := make([]int, 0, n)
+		for i := range matchL {
+			if matchL[i] == unmatched {
+				dist[i] = 0
+				queue = append(queue, i)
+			} else {
+				dist[i] = -1
+			}
+		}
+		found := false
+		for len(queue) > 0 {
+			u := queue[0]
+			queue = queue[1:]
+			for _, v := range adj[u] {
+				w := matchR[v]
+				if w == unmatched {
+					found = true
+				} else if dist[w] == -1 {
+					dist[w] = dist[u] + 1
+					queue = append(queue, w)
+				}
+			}
+		}
+		return found
+	}
+
+	var dfs func(u int) bool
+	dfs = func(u int) bool {
+		for _, v := range adj[u] {
+			w := matchR[v]
+			if w == unmatched || (dist[w] == dist[u]+1 && dfs(w)) {
+				matchL[u] = v
+				matchR[v] = u
+				return true
+			}
+		}
+		dist[u] = -1
+		return false
+	}
+
+	matching := 0
+	for bfs() {
+		for i := range matchL {
+			if matchL[i] == unmatched && dfs(i) {
+				matching++
+			}
+		}
+	}
+	return matching, matchL
+}
@@ -0,0 +1,53 @@
+package deepequal
+
+import "testing"
+
+type taggedStruct struct {
+	ID         string `deepequal:"ignore"`
+	Tags       []string
+	Unordered  []string `deepequal:"unordered"`
+	Score      float64  `deepequal:"tolerance=0.001"`
+	NilIsEmpty []string `deepequal:"nilAsEmpty"`
+}
+
+func TestTagIgnore(t *testing.T) {
+	a := taggedStruct{ID: "a"}
+	b := taggedStruct{ID: "b"}
+	if equal, err := DeepEqual(a, b); !equal {
+		t.Errorf("expected ignored field to not affect equality: %v", err)
+	}
+}
+
+func TestTagUnordered(t *testing.T) {
+	a := taggedStruct{Unordered: []string{"x", "y", "z"}}
+	b := taggedStruct{Unordered: []string{"z", "x", "y"}}
+	if equal, err := DeepEqual(a, b); !equal {
+		t.Errorf("expected reordered slice to be equal: %v", err)
+	}
+
+	c := taggedStruct{Unordered: []string{"x", "y", "y"}}
+	if equal, _ := DeepEqual(a, c); equal {
+		t.Errorf("expected slices with different multisets to differ")
+	}
+}
+
+func TestTagTolerance(t *testing.T) {
+	a := taggedStruct{Score: 1.0}
+	b := taggedStruct{Score: 1.0005}
+	if equal, err := DeepEqual(a, b); !equal {
+		t.Errorf("expected scores within tolerance to be equal: %v", err)
+	}
+
+	c := taggedStruct{Score: 1.1}
+	if equal, _ := DeepEqual(a, c); equal {
+		t.Errorf("expected scores outside tolerance to differ")
+	}
+}
+
+func TestTagNilAsEmpty(t *testing.T) {
+	a := taggedStruct{NilIsEmpty: nil}
+	b := taggedStruct{NilIsEmpty: []string{}}
+	if equal, err := DeepEqual(a, b); !equal {
+		t.Errorf("expected nil and empty slice to be equal: %v", err)
+	}
+}
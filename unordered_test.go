@@ -0,0 +1,137 @@
+package deepequal
+
+import (
+	"reflect"
+	"testing"
+)
+
+type taggedItem struct {
+	Values []int
+}
+
+type tol struct {
+	V float64
+}
+
+// TestWithUnorderedSlicesHonorsOtherOptions ensures that options composed
+// alongside WithUnorderedSlices, such as WithFloatTolerance, still apply to
+// the elements being matched rather than being silently dropped.
+func TestWithUnorderedSlicesHonorsOtherOptions(t *testing.T) {
+	a := []tol{{1.0}}
+	b := []tol{{1.0005}}
+	if equal, err := DeepEqualWithOptions(a, b, WithUnorderedSlices([]tol{}), WithFloatTolerance(0.001, 0)); !equal {
+		t.Errorf("expected tolerance to apply to unordered elements: %v", err)
+	}
+	if equal, _ := DeepEqualWithOptions(a, b, WithUnorderedSlices([]tol{})); equal {
+		t.Errorf("expected unordered comparison without tolerance to still distinguish the values")
+	}
+}
+
+// TestOptsAllowComparableFastPath guards against the map-counting fast path
+// in unorderedEqual being dead code: DeepEqual/DeepDiff/DeepEqualWithOptions
+// always pass a non-nil *options (at minimum defaultOptions()), so the fast
+// path must still activate for ordinary comparable element types such as
+// string or int, and only back off for options that would make it disagree
+// with plain ==.
+func TestOptsAllowComparableFastPath(t *testing.T) {
+	def := defaultOptions()
+	if !optsAllowComparableFastPath(&def, reflect.TypeOf("")) {
+		t.Errorf("expected default options to allow the fast path for string elements")
+	}
+	if !optsAllowComparableFastPath(&def, reflect.TypeOf(0)) {
+		t.Errorf("expected default options to allow the fast path for int elements")
+	}
+	if optsAllowComparableFastPath(&def, reflect.TypeOf(0.0)) {
+		t.Errorf("expected default options (naNEqual=true) to disallow the fast path for float elements")
+	}
+
+	tol := options{floatAbsTolerance: 0.001}
+	if optsAllowComparableFastPath(&tol, reflect.TypeOf("")) {
+		t.Errorf("expected a float tolerance to disallow the fast path even for non-float elements")
+	}
+}
+
+// TestUnorderedMatchesEqualValuedPointers ensures that distinct pointers to
+// equal values are matched, rather than being treated as unequal solely
+// because their identities differ.
+func TestUnorderedMatchesEqualValuedPointers(t *testing.T) {
+	x, y := 1, 1
+	a := []*int{&x}
+	b := []*int{&y}
+	if equal, err := DeepEqualWithOptions(a, b, WithUnorderedSlices([]*int{})); !equal {
+		t.Errorf("expected pointers to equal values to match: %v", err)
+	}
+
+	z := 2
+	c := []*int{&z}
+	if equal, _ := DeepEqualWithOptions(a, c, WithUnorderedSlices([]*int{})); equal {
+		t.Errorf("expected pointers to different values to differ")
+	}
+}
+
+func TestWithUnorderedSlices(t *testing.T) {
+	a := []string{"a", "b", "c"}
+	b := []string{"c", "a", "b"}
+	if equal, err := DeepEqualWithOptions(a, b, WithUnorderedSlices([]string{})); !equal {
+		t.Errorf("expected reordered slices to be equal: %v", err)
+	}
+
+	c := []string{"a", "b", "b"}
+	if equal, _ := DeepEqualWithOptions(a, c, WithUnorderedSlices([]string{})); equal {
+		t.Errorf("expected slices with different multisets to differ")
+	}
+}
+
+func TestWithUnorderedSlicesOnlyAppliesToTaggedType(t *testing.T) {
+	a := [][]string{{"a"}, {"b"}}
+	b := [][]string{{"b"}, {"a"}}
+	// WithUnorderedSlices is registered for []string, not [][]string, so the
+	// outer slice is still compared in order.
+	if equal, _ := DeepEqualWithOptions(a, b, WithUnorderedSlices([]string{})); equal {
+		t.Errorf("expected outer slice order to still matter")
+	}
+}
+
+func TestUnorderedMatchesNonComparableElements(t *testing.T) {
+	a := []taggedItem{
+		{Values: []int{1, 2}},
+		{Values: []int{1}},
+	}
+	b := []taggedItem{
+		{Values: []int{1}},
+		{Values: []int{1, 2}},
+	}
+	if equal, err := DeepEqualWithOptions(a, b, WithUnorderedSlices([]taggedItem{})); !equal {
+		t.Errorf("expected a valid unordered pairing to be found: %v", err)
+	}
+
+	c := []taggedItem{
+		{Values: []int{1, 2}},
+		{Values: []int{3}},
+	}
+	if equal, _ := DeepEqualWithOptions(a, c, WithUnorderedSlices([]taggedItem{})); equal {
+		t.Errorf("expected slices with no valid pairing to differ")
+	}
+}
+
+// TestHopcroftKarpFindsMatchingGreedyWouldMiss exercises hopcroftKarp
+// directly with a graph shaped so that processing left vertices in index
+// order and greedily claiming the first available neighbor gets stuck,
+// even though a perfect matching exists (0->1, 1->0). This is the case in
+// which unorderedEqual falls back to bipartiteUnorderedEqual.
+func TestHopcroftKarpFindsMatchingGreedyWouldMiss(t *testing.T) {
+	adj := [][]int{
+		{0, 1}, // left 0 can match right 0 or right 1
+		{0},    // left 1 can only match right 0
+	}
+	matching, matchL := hopcroftKarp(adj, 2)
+	if matching != 2 {
+		t.Fatalf("expected a perfect matching of size 2, got %d (matchL=%v)", matching, matchL)
+	}
+	if matchL[1] != 0 {
+		t.Errorf("expected left 1 to match right 0, got %d", matchL[1])
+	}
+	if matchL[0] != 1 {
+		t.Errorf("expected left 0 to match right 1, got %d", matchL[0])
+	}
+}